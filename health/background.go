@@ -0,0 +1,279 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CheckOption configures how a registered Check is scheduled and evaluated.
+type CheckOption func(*checkConfig)
+
+type checkConfig struct {
+	interval         time.Duration
+	timeout          time.Duration
+	initialDelay     time.Duration
+	failureThreshold int
+}
+
+// backgroundEnabled reports whether the check should be run on a periodic
+// schedule in the background rather than inline on every HTTP request.
+func (c checkConfig) backgroundEnabled() bool {
+	return c.interval > 0
+}
+
+// WithInterval runs the check on a background goroutine every d, caching its
+// result for the HTTP handler to read instead of running it inline.
+func WithInterval(d time.Duration) CheckOption {
+	return func(c *checkConfig) { c.interval = d }
+}
+
+// WithTimeout bounds how long a single background run of the check is
+// allowed to take before it is considered failed.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *checkConfig) { c.timeout = d }
+}
+
+// WithInitialDelay delays the first background run of the check by d,
+// useful for checks against dependencies that need time to come up.
+func WithInitialDelay(d time.Duration) CheckOption {
+	return func(c *checkConfig) { c.initialDelay = d }
+}
+
+// WithFailureThreshold only reports the check as unhealthy after n
+// consecutive background failures, absorbing transient blips.
+func WithFailureThreshold(n int) CheckOption {
+	return func(c *checkConfig) { c.failureThreshold = n }
+}
+
+// checkResult is the outcome of a single run of a Check: whether it failed
+// and how long it took.
+type checkResult struct {
+	err      error
+	duration time.Duration
+}
+
+// registeredCheck pairs a Check with its scheduling options and, when run in
+// the background, the last cached result. checkType and name identify it
+// for logging and for the chunk0-2 Prometheus metrics, which the background
+// loop records through handler.
+type registeredCheck struct {
+	check     Check
+	config    checkConfig
+	checkType string
+	name      string
+	handler   *checksHandler
+
+	mu              sync.RWMutex
+	last            checkResult
+	lastCheckedAt   time.Time
+	consecutiveFail int
+}
+
+func newRegisteredCheck(ch *checksHandler, checkType, name string, check Check, opts []CheckOption) *registeredCheck {
+	var cfg checkConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &registeredCheck{check: check, config: cfg, checkType: checkType, name: name, handler: ch}
+}
+
+// run evaluates the check, the inline, synchronous default used whenever no
+// interval has been configured.
+func (rc *registeredCheck) run() error {
+	if rc.check == nil {
+		return nil
+	}
+	return rc.check()
+}
+
+// runWithTimeout runs the check with a best-effort deadline: the check
+// itself takes no context, so a slow check that ignores cancellation keeps
+// running in its own goroutine, but the background loop stops waiting on it
+// once the timeout elapses.
+func (rc *registeredCheck) runWithTimeout(ctx context.Context) error {
+	if rc.check == nil {
+		return nil
+	}
+	if rc.config.timeout <= 0 {
+		return rc.check()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rc.config.timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() { result <- rc.check() }()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// evaluate runs the check, caches the outcome, applying the configured
+// failure threshold before reporting the check unhealthy, and records it in
+// the Prometheus metrics so a dependency that flaps in the background moves
+// health_check_status/health_checks_total without anyone probing the HTTP
+// endpoint.
+func (rc *registeredCheck) evaluate(ctx context.Context) {
+	start := time.Now()
+	err := rc.runWithTimeout(ctx)
+	duration := time.Since(start)
+
+	rc.mu.Lock()
+	if err != nil {
+		rc.consecutiveFail++
+	} else {
+		rc.consecutiveFail = 0
+	}
+
+	reported := err
+	if err != nil && rc.config.failureThreshold > 0 && rc.consecutiveFail < rc.config.failureThreshold {
+		reported = nil
+	}
+	rc.last = checkResult{err: reported, duration: duration}
+	rc.lastCheckedAt = time.Now()
+	rc.mu.Unlock()
+
+	if rc.handler != nil {
+		rc.handler.recordCheck(rc.checkType, rc.name, reported)
+	}
+}
+
+// cachedResult returns the last background result for the check. The
+// second return value is false until the check has run at least once.
+func (rc *registeredCheck) cachedResult() (checkResult, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	if rc.lastCheckedAt.IsZero() {
+		return checkResult{}, false
+	}
+	return rc.last, true
+}
+
+// evaluateInline returns the current status of the check: the cached
+// background result when a schedule is configured and has produced one
+// already, or a fresh synchronous run otherwise. The synchronous fallback
+// also covers a check registered with WithInterval whose background loop
+// hasn't reported in yet (still in its WithInitialDelay window, or because
+// Start was never called) — without it, such a check would wrongly read as
+// permanently failed.
+func (rc *registeredCheck) evaluateInline() checkResult {
+	if res, ok := rc.cachedResult(); ok {
+		return res
+	}
+	start := time.Now()
+	err := rc.run()
+	return checkResult{err: err, duration: time.Since(start)}
+}
+
+func (rc *registeredCheck) backgroundLoop(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if rc.config.initialDelay > 0 {
+		select {
+		case <-time.After(rc.config.initialDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	rc.evaluate(ctx)
+
+	ticker := time.NewTicker(rc.config.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rc.evaluate(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// AddLivenessWithOptions registers a liveness check along with a schedule
+// and timeout. When opts leaves the interval unset, the check runs
+// synchronously on every request, same as AddLiveness.
+func (ch *checksHandler) AddLivenessWithOptions(name string, check Check, opts ...CheckOption) {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+	if ch.logger != nil {
+		ch.logger.WithFields(logrus.Fields{
+			"liveness_path":  ch.livenessPath,
+			"readiness_path": ch.readinessPath,
+			"name":           name,
+		}).Warn("adding liveness check")
+	}
+	ch.livenessChecks[name] = newRegisteredCheck(ch, livenessCheckType, name, check, opts)
+}
+
+// AddReadinessWithOptions registers a readiness check along with a schedule
+// and timeout. When opts leaves the interval unset, the check runs
+// synchronously on every request, same as AddReadiness.
+func (ch *checksHandler) AddReadinessWithOptions(name string, check Check, opts ...CheckOption) {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+	if ch.logger != nil {
+		ch.logger.WithFields(logrus.Fields{
+			"liveness_path":  ch.livenessPath,
+			"readiness_path": ch.readinessPath,
+			"name":           name,
+		}).Warn("adding readiness check")
+	}
+	ch.readinessChecks[name] = newRegisteredCheck(ch, readinessCheckType, name, check, opts)
+}
+
+// Start launches a background goroutine for every registered check that was
+// given a schedule via WithInterval, evaluating it on its configured
+// interval and caching the result for the HTTP handlers to read. Checks
+// without a schedule continue to run inline on every request.
+func (ch *checksHandler) Start(ctx context.Context) error {
+	ch.lock.Lock()
+	if ch.cancel != nil {
+		ch.lock.Unlock()
+		return errors.New("health: checker already started")
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	ch.cancel = cancel
+
+	var scheduled []*registeredCheck
+	for _, rc := range ch.livenessChecks {
+		if rc.config.backgroundEnabled() {
+			scheduled = append(scheduled, rc)
+		}
+	}
+	for _, rc := range ch.readinessChecks {
+		if rc.config.backgroundEnabled() {
+			scheduled = append(scheduled, rc)
+		}
+	}
+	ch.lock.Unlock()
+
+	ch.wg.Add(len(scheduled))
+	for _, rc := range scheduled {
+		go rc.backgroundLoop(runCtx, &ch.wg)
+	}
+	return nil
+}
+
+// Stop cancels all background check goroutines and waits for them to exit.
+func (ch *checksHandler) Stop() {
+	ch.lock.Lock()
+	cancel := ch.cancel
+	ch.cancel = nil
+	ch.lock.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	ch.wg.Wait()
+}
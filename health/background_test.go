@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegisteredCheckEvaluateFailureThreshold(t *testing.T) {
+	wantErr := errors.New("boom")
+	rc := newRegisteredCheck(nil, readinessCheckType, "dep", func() error { return wantErr }, []CheckOption{
+		WithInterval(1), // only needed to mark the check as background-enabled
+		WithFailureThreshold(2),
+	})
+
+	rc.evaluate(context.Background())
+	if res, ok := rc.cachedResult(); !ok || res.err != nil {
+		t.Fatalf("after 1 failure (threshold 2): got result %+v, ok=%v, want a nil error", res, ok)
+	}
+
+	rc.evaluate(context.Background())
+	if res, ok := rc.cachedResult(); !ok || res.err != wantErr {
+		t.Fatalf("after 2 failures (threshold 2): got result %+v, ok=%v, want err %v", res, ok, wantErr)
+	}
+}
+
+func TestRegisteredCheckEvaluateFailureThresholdResets(t *testing.T) {
+	wantErr := errors.New("boom")
+	failing := true
+	rc := newRegisteredCheck(nil, readinessCheckType, "dep", func() error {
+		if failing {
+			return wantErr
+		}
+		return nil
+	}, []CheckOption{
+		WithInterval(1),
+		WithFailureThreshold(2),
+	})
+
+	rc.evaluate(context.Background())
+	failing = false
+	rc.evaluate(context.Background())
+	if res, ok := rc.cachedResult(); !ok || res.err != nil {
+		t.Fatalf("after a success resetting the streak: got result %+v, ok=%v, want a nil error", res, ok)
+	}
+
+	failing = true
+	rc.evaluate(context.Background())
+	if res, ok := rc.cachedResult(); !ok || res.err != nil {
+		t.Fatalf("after 1 failure post-reset (threshold 2): got result %+v, ok=%v, want a nil error", res, ok)
+	}
+}
+
+func TestRegisteredCheckEvaluateInlineFallsBackWhenUncached(t *testing.T) {
+	calls := 0
+	wantErr := errors.New("dependency down")
+	rc := newRegisteredCheck(nil, readinessCheckType, "dep", func() error {
+		calls++
+		return wantErr
+	}, []CheckOption{WithInterval(1)})
+
+	res := rc.evaluateInline()
+	if res.err != wantErr {
+		t.Fatalf("evaluateInline before any background run: got err %v, want %v", res.err, wantErr)
+	}
+	if calls != 1 {
+		t.Fatalf("evaluateInline before any background run: check ran %d times, want 1", calls)
+	}
+}
+
+func TestRegisteredCheckEvaluateInlineUsesCacheOnceWarm(t *testing.T) {
+	calls := 0
+	rc := newRegisteredCheck(nil, readinessCheckType, "dep", func() error {
+		calls++
+		return nil
+	}, []CheckOption{WithInterval(1)})
+
+	rc.evaluate(context.Background())
+	if calls != 1 {
+		t.Fatalf("evaluate: check ran %d times, want 1", calls)
+	}
+
+	if res := rc.evaluateInline(); res.err != nil {
+		t.Fatalf("evaluateInline after a background run: got err %v, want nil", res.err)
+	}
+	if calls != 1 {
+		t.Fatalf("evaluateInline after a background run re-ran the check: got %d calls, want 1 (cached)", calls)
+	}
+}
@@ -0,0 +1,106 @@
+// Package checks provides ready-made health.Check constructors for common
+// dependencies, so callers don't have to hand-roll a probe for every
+// database or upstream service they depend on.
+//
+// health.Check is a plain func() error with no context parameter, so a
+// constructor can only honor a deadline if it's given one explicitly.
+// SQLPing, GRPCHealth and Redis therefore take a timeout argument, e.g.
+// ch.AddReadiness("postgres", checks.SQLPing(db, 2*time.Second)).
+package checks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/SaladkevichM/atlas-app-toolkit/health"
+)
+
+// HTTPGet returns a Check that performs a GET request against url and fails
+// unless a response is received within timeout with status expectedStatus.
+func HTTPGet(url string, expectedStatus int, timeout time.Duration) health.Check {
+	client := &http.Client{Timeout: timeout}
+	return func() error {
+		resp, err := client.Get(url)
+		if err != nil {
+			return fmt.Errorf("http get %s: %w", url, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != expectedStatus {
+			return fmt.Errorf("http get %s: unexpected status %d, want %d", url, resp.StatusCode, expectedStatus)
+		}
+		return nil
+	}
+}
+
+// TCPDial returns a Check that fails unless a TCP connection to addr can be
+// established within timeout.
+func TCPDial(addr string, timeout time.Duration) health.Check {
+	return func() error {
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return fmt.Errorf("tcp dial %s: %w", addr, err)
+		}
+		return conn.Close()
+	}
+}
+
+// SQLPing returns a Check that fails unless db responds to a ping within
+// timeout. The timeout parameter is required, not optional, since Check
+// carries no context for SQLPing to pull a deadline from otherwise.
+func SQLPing(db *sql.DB, timeout time.Duration) health.Check {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("sql ping: %w", err)
+		}
+		return nil
+	}
+}
+
+// GRPCHealth returns a Check that queries the grpc.health.v1 Health service
+// exposed over conn for service (empty for the server as a whole) and fails
+// unless it reports SERVING within timeout. The timeout parameter is
+// required, not optional, since Check carries no context for GRPCHealth to
+// pull a deadline from otherwise.
+func GRPCHealth(conn *grpc.ClientConn, service string, timeout time.Duration) health.Check {
+	client := grpc_health_v1.NewHealthClient(conn)
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			return fmt.Errorf("grpc health check %q: %w", service, err)
+		}
+		if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc health check %q: status %s", service, resp.Status)
+		}
+		return nil
+	}
+}
+
+// Redis returns a Check that fails unless client responds to a PING within
+// timeout. The timeout parameter is required, not optional, since Check
+// carries no context for Redis to pull a deadline from otherwise.
+func Redis(client redis.UniversalClient, timeout time.Duration) health.Check {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("redis ping: %w", err)
+		}
+		return nil
+	}
+}
@@ -1,36 +1,72 @@
 package health
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
+// Check type labels used on the Prometheus metrics and in logging.
+const (
+	livenessCheckType  = "liveness"
+	readinessCheckType = "readiness"
+)
+
+// Check is a function that performs a single health or readiness check. It
+// should return a non-nil error if and only if the check failed.
+type Check func() error
+
+// checkOutcome is the result of running a single named Check.
+type checkOutcome struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
 type checksHandler struct {
 	lock sync.RWMutex
 
 	livenessPath   string
-	livenessChecks map[string]Check
+	livenessChecks map[string]*registeredCheck
 
 	readinessPath   string
-	readinessChecks map[string]Check
+	readinessChecks map[string]*registeredCheck
 
 	// if true first found error will fail the check stage
 	failFast bool
 	logger   *logrus.Logger
+
+	registerer  prometheus.Registerer
+	checkStatus *prometheus.GaugeVec
+	checkTotal  *prometheus.CounterVec
+
+	defaultFormat ResponseFormat
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // Checker ...
 type Checker interface {
 	AddLiveness(name string, check Check)
 	AddReadiness(name string, check Check)
+	AddLivenessWithOptions(name string, check Check, opts ...CheckOption)
+	AddReadinessWithOptions(name string, check Check, opts ...CheckOption)
 	Handler() http.Handler
 	RegisterHandler(mux *http.ServeMux)
 	SetFailFast(failFast bool)
 	GetFailFast() bool
+	Start(ctx context.Context) error
+	Stop()
 }
 
 // NewchecksHandler accepts two strings: health and ready paths.
@@ -48,9 +84,9 @@ func newChecksHandler(healthPath, readyPath string) *checksHandler {
 	}
 	ch := &checksHandler{
 		livenessPath:    healthPath,
-		livenessChecks:  map[string]Check{},
+		livenessChecks:  map[string]*registeredCheck{},
 		readinessPath:   readyPath,
-		readinessChecks: map[string]Check{},
+		readinessChecks: map[string]*registeredCheck{},
 		logger:          nil,
 	}
 	return ch
@@ -62,6 +98,7 @@ func NewChecksHandlerWithOptions(healthPath, readyPath string, options ...func(*
 	for _, option := range options {
 		option(ch)
 	}
+	ch.initMetrics()
 
 	return ch
 }
@@ -82,31 +119,11 @@ func WithLogger(logger *logrus.Logger) func(*checksHandler) {
 }
 
 func (ch *checksHandler) AddLiveness(name string, check Check) {
-	ch.lock.Lock()
-	defer ch.lock.Unlock()
-	if ch.logger != nil {
-		ch.logger.WithFields(logrus.Fields{
-			"liveness_path":  ch.livenessPath,
-			"readiness_path": ch.readinessPath,
-			"name":           name,
-		}).Warn("adding liveness check")
-	}
-
-	ch.livenessChecks[name] = check
+	ch.AddLivenessWithOptions(name, check)
 }
 
 func (ch *checksHandler) AddReadiness(name string, check Check) {
-	ch.lock.Lock()
-	defer ch.lock.Unlock()
-	if ch.logger != nil {
-		ch.logger.WithFields(logrus.Fields{
-			"liveness_path":  ch.livenessPath,
-			"readiness_path": ch.readinessPath,
-			"name":           name,
-		}).Warn("adding liveness check")
-	}
-
-	ch.readinessChecks[name] = check
+	ch.AddReadinessWithOptions(name, check)
 }
 
 // Handler returns a new http.Handler for the given health checker
@@ -133,6 +150,8 @@ func (ch *checksHandler) RegisterHandler(mux *http.ServeMux) {
 	ch.registerMux(mux)
 }
 
+// registerMux wires up the aggregate liveness/readiness endpoints as well as
+// a prefix handler for their per-check sub-paths, e.g. GET /livez/<name>.
 func (ch *checksHandler) registerMux(mux *http.ServeMux) {
 	if ch.logger != nil {
 		ch.logger.WithFields(logrus.Fields{
@@ -141,15 +160,17 @@ func (ch *checksHandler) registerMux(mux *http.ServeMux) {
 		}).Warn("registering endpoints for health checker")
 	}
 	mux.HandleFunc(ch.readinessPath, ch.readyEndpoint)
+	mux.HandleFunc(ch.readinessPath+"/", ch.singleCheckHandler(ch.readinessPath, readinessCheckType, ch.readinessChecks))
 	mux.HandleFunc(ch.livenessPath, ch.healthEndpoint)
+	mux.HandleFunc(ch.livenessPath+"/", ch.singleCheckHandler(ch.livenessPath, livenessCheckType, ch.livenessChecks))
 }
 
 func (ch *checksHandler) healthEndpoint(rw http.ResponseWriter, r *http.Request) {
-	ch.handle(rw, r, ch.livenessChecks)
+	ch.handle(rw, r, ch.livenessPath, livenessCheckType, ch.livenessChecks)
 }
 
 func (ch *checksHandler) readyEndpoint(rw http.ResponseWriter, r *http.Request) {
-	ch.handle(rw, r, ch.readinessChecks)
+	ch.handle(rw, r, ch.readinessPath, readinessCheckType, ch.readinessChecks)
 }
 
 func checkLogger(logger *logrus.Entry) (*logrus.Entry, bool) {
@@ -161,7 +182,95 @@ func checkLogger(logger *logrus.Entry) (*logrus.Entry, bool) {
 	return logger, true
 }
 
-func (ch *checksHandler) handle(rw http.ResponseWriter, r *http.Request, checksSets ...map[string]Check) {
+// excludedChecks returns the set of check names passed via one or more
+// ?exclude=<name> query parameters, to be skipped when computing status.
+func excludedChecks(r *http.Request) map[string]bool {
+	values := r.URL.Query()["exclude"]
+	if len(values) == 0 {
+		return nil
+	}
+	excluded := make(map[string]bool, len(values))
+	for _, name := range values {
+		excluded[name] = true
+	}
+	return excluded
+}
+
+// isVerbose reports whether the request asked for the kube-apiserver style
+// plain-text breakdown via the legacy ?verbose=1 flag.
+func isVerbose(r *http.Request) bool {
+	return r.URL.Query().Get("verbose") == "1"
+}
+
+// writeVerbose renders a plain-text body listing each check outcome as
+// "[+]<name> ok" / "[-]<name> failed: <err>", followed by a trailing summary
+// line, mirroring kube-apiserver's healthz verbose output.
+func writeVerbose(rw http.ResponseWriter, path string, results []checkOutcome, status int) {
+	sort.Slice(results, func(i, j int) bool { return results[i].name < results[j].name })
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.WriteHeader(status)
+
+	for _, res := range results {
+		if res.err != nil {
+			fmt.Fprintf(rw, "[-]%s failed: %v\n", res.name, res.err)
+		} else {
+			fmt.Fprintf(rw, "[+]%s ok\n", res.name)
+		}
+	}
+
+	checkType := strings.TrimPrefix(path, "/")
+	if status == http.StatusOK {
+		fmt.Fprintf(rw, "%s check passed\n", checkType)
+	} else {
+		fmt.Fprintf(rw, "%s check failed\n", checkType)
+	}
+}
+
+// singleCheckHandler serves GET <path>/<name>, reporting the status of a
+// single registered check as evaluateInline returns it: the cached
+// background result when there is one, otherwise a fresh run.
+func (ch *checksHandler) singleCheckHandler(path, checkType string, checks map[string]*registeredCheck) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, path+"/")
+		if name == "" {
+			ch.handle(rw, r, path, checkType, checks)
+			return
+		}
+
+		ch.lock.RLock()
+		rc, ok := checks[name]
+		ch.lock.RUnlock()
+		if !ok {
+			http.NotFound(rw, r)
+			return
+		}
+
+		res := rc.evaluateInline()
+		ch.recordCheck(checkType, name, res.err)
+		status := http.StatusOK
+		if res.err != nil {
+			status = http.StatusServiceUnavailable
+		}
+
+		outcome := []checkOutcome{{name: name, err: res.err, duration: res.duration}}
+		switch ch.resolveFormat(r) {
+		case FormatJSON:
+			writeJSON(rw, checkType, outcome, status)
+		case FormatVerboseText:
+			writeVerbose(rw, path, outcome, status)
+		default:
+			rw.WriteHeader(status)
+		}
+	}
+}
+
+func (ch *checksHandler) handle(rw http.ResponseWriter, r *http.Request, path, checkType string, checks map[string]*registeredCheck) {
 	logger := ch.logger
 	ctxLogger, ok := checkLogger(ctxlogrus.Extract(r.Context()))
 	if ok {
@@ -180,44 +289,49 @@ func (ch *checksHandler) handle(rw http.ResponseWriter, r *http.Request, checksS
 		return
 	}
 
-	errors := map[string]error{}
-	status := http.StatusOK
+	excluded := excludedChecks(r)
+
+	// Snapshot the check set under lock so that running the checks
+	// themselves, which may be slow, doesn't block registration of new
+	// checks elsewhere.
 	ch.lock.RLock()
-	defer ch.lock.RUnlock()
+	snapshot := make(map[string]*registeredCheck, len(checks))
+	for name, rc := range checks {
+		snapshot[name] = rc
+	}
+	ch.lock.RUnlock()
 
-	for _, checks := range checksSets {
-		for name, check := range checks {
-			if check == nil {
-				continue
-			}
-			if err := check(); err != nil {
-				if logger != nil {
-					logger.WithFields(logrus.Fields{
-						"liveness_path":  ch.livenessPath,
-						"readiness_path": ch.readinessPath,
-						"url":            r.URL.RawPath,
-					}).WithError(err).Error("health check returned error")
-				}
-				status = http.StatusServiceUnavailable
-				errors[name] = err
-				if ch.failFast {
-					rw.WriteHeader(status)
-					return
-				}
+	status := http.StatusOK
+	var results []checkOutcome
+	for name, rc := range snapshot {
+		if rc == nil || excluded[name] {
+			continue
+		}
+		res := rc.evaluateInline()
+		ch.recordCheck(checkType, name, res.err)
+		if res.err != nil {
+			status = http.StatusServiceUnavailable
+			if logger != nil {
+				logger.WithFields(logrus.Fields{
+					"liveness_path":  ch.livenessPath,
+					"readiness_path": ch.readinessPath,
+					"url":            r.URL.RawPath,
+					"check":          name,
+				}).WithError(res.err).Error("health check returned error")
 			}
 		}
+		results = append(results, checkOutcome{name: name, err: res.err, duration: res.duration})
+		if res.err != nil && ch.failFast {
+			break
+		}
 	}
-	rw.WriteHeader(status)
 
-	return
-
-	// Uncomment to write errors and get non-empty response
-	// rw.Header().Set("Content-Type", "application/json; charset=utf-8")
-	// if status == http.StatusOK {
-	// 	rw.Write([]byte("{}\n"))
-	// } else {
-	// 	encoder := json.NewEncoder(rw)
-	// 	encoder.SetIndent("", "    ")
-	// 	encoder.Encode(errors)
-	// }
+	switch ch.resolveFormat(r) {
+	case FormatJSON:
+		writeJSON(rw, checkType, results, status)
+	case FormatVerboseText:
+		writeVerbose(rw, path, results, status)
+	default:
+		rw.WriteHeader(status)
+	}
 }
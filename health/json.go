@@ -0,0 +1,103 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ResponseFormat selects how the aggregate liveness/readiness endpoints
+// render their response body.
+type ResponseFormat int
+
+const (
+	// FormatPlain writes only the HTTP status code with an empty body.
+	// This is the default, preserved for backwards compatibility.
+	FormatPlain ResponseFormat = iota
+	// FormatJSON writes a structured JSON body describing every check.
+	FormatJSON
+	// FormatVerboseText writes the kube-apiserver style plain-text
+	// breakdown produced by writeVerbose.
+	FormatVerboseText
+)
+
+// WithDefaultFormat sets the ResponseFormat used when a request doesn't
+// explicitly select one via ?format=, the legacy ?verbose=1 flag, or an
+// Accept header.
+func WithDefaultFormat(format ResponseFormat) func(*checksHandler) {
+	return func(c *checksHandler) {
+		c.defaultFormat = format
+	}
+}
+
+// resolveFormat determines the response format for a request: an explicit
+// ?format= query parameter wins, then the legacy ?verbose=1 flag, then the
+// Accept header, falling back to the handler's configured default.
+func (ch *checksHandler) resolveFormat(r *http.Request) ResponseFormat {
+	switch r.URL.Query().Get("format") {
+	case "json":
+		return FormatJSON
+	case "verbose":
+		return FormatVerboseText
+	case "plain":
+		return FormatPlain
+	}
+
+	if isVerbose(r) {
+		return FormatVerboseText
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		return FormatJSON
+	}
+
+	return ch.defaultFormat
+}
+
+// checkStatusJSON is a single check's entry in the JSON response body.
+type checkStatusJSON struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// checksResponseJSON is the stable schema returned when FormatJSON is
+// selected: {"status":"ok|fail","checks":[...]}.
+type checksResponseJSON struct {
+	Status string            `json:"status"`
+	Checks []checkStatusJSON `json:"checks"`
+}
+
+// writeJSON renders results as the checksResponseJSON schema.
+func writeJSON(rw http.ResponseWriter, checkType string, results []checkOutcome, status int) {
+	sort.Slice(results, func(i, j int) bool { return results[i].name < results[j].name })
+
+	resp := checksResponseJSON{
+		Status: "ok",
+		Checks: make([]checkStatusJSON, 0, len(results)),
+	}
+	if status != http.StatusOK {
+		resp.Status = "fail"
+	}
+
+	for _, res := range results {
+		entry := checkStatusJSON{
+			Name:       res.name,
+			Type:       checkType,
+			Status:     "ok",
+			DurationMs: res.duration.Milliseconds(),
+		}
+		if res.err != nil {
+			entry.Status = "fail"
+			entry.Error = res.err.Error()
+		}
+		resp.Checks = append(resp.Checks, entry)
+	}
+
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(resp)
+}
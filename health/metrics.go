@@ -0,0 +1,54 @@
+package health
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const metricsNamespace = "health"
+
+// WithPrometheusRegisterer registers the checker's Prometheus metrics
+// (check status and check run totals) with the given Registerer. When no
+// registerer is configured, no metrics are collected.
+func WithPrometheusRegisterer(r prometheus.Registerer) func(*checksHandler) {
+	return func(c *checksHandler) {
+		c.registerer = r
+	}
+}
+
+// initMetrics creates and registers the checker's metrics, if a Registerer
+// was configured via WithPrometheusRegisterer.
+func (ch *checksHandler) initMetrics() {
+	if ch.registerer == nil {
+		return
+	}
+
+	ch.checkStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "check_status",
+		Help:      "Result of the last run of a health check, by type and name (1 = success, 0 = failure).",
+	}, []string{"type", "name"})
+
+	ch.checkTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "checks_total",
+		Help:      "Total number of health check runs, by type, name and status.",
+	}, []string{"type", "name", "status"})
+
+	ch.registerer.MustRegister(ch.checkStatus, ch.checkTotal)
+}
+
+// recordCheck records the outcome of running a single check in the
+// configured Prometheus metrics. It is a no-op when no registerer was
+// configured.
+func (ch *checksHandler) recordCheck(checkType, name string, err error) {
+	if ch.checkStatus == nil {
+		return
+	}
+
+	value := 1.0
+	status := "success"
+	if err != nil {
+		value = 0
+		status = "error"
+	}
+	ch.checkStatus.WithLabelValues(checkType, name).Set(value)
+	ch.checkTotal.WithLabelValues(checkType, name, status).Inc()
+}